@@ -0,0 +1,139 @@
+package io
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// formatSniffBytes is how much of a file OpenVectorBuffer reads before
+// asking registered formats whether they recognize it. 64 bytes is enough
+// to cover every built-in header's magic/endianness prefix.
+const formatSniffBytes = 64
+
+// FormatProbe reports whether head - the first formatSniffBytes bytes of a
+// file, or fewer if the file is shorter - looks like this format.
+type FormatProbe func(head []byte) bool
+
+// FormatOpener opens fname as a VectorBuffer once its FormatProbe has
+// matched.
+type FormatOpener func(fname string) (VectorBuffer, error)
+
+// FormatRegistry maps snapshot formats to the probe/opener pair that
+// recognizes and reads them, the same way debug/elf, debug/macho, and
+// debug/pe each sniff their own magic number. Callers normally use the
+// package-level Register, Formats, and OpenVectorBuffer, which operate on
+// a shared default registry; FormatRegistry is exported so third-party
+// backends living outside this package can keep their own.
+type FormatRegistry struct {
+	mu     sync.Mutex
+	names  []string
+	probes map[string]FormatProbe
+	opens  map[string]FormatOpener
+}
+
+// NewFormatRegistry returns an empty registry.
+func NewFormatRegistry() *FormatRegistry {
+	return &FormatRegistry{
+		probes: map[string]FormatProbe{},
+		opens:  map[string]FormatOpener{},
+	}
+}
+
+// Register adds (or replaces) the probe/opener pair for name. Formats are
+// tried in the order they were first registered.
+func (r *FormatRegistry) Register(name string, probe FormatProbe, open FormatOpener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.probes[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.probes[name] = probe
+	r.opens[name] = open
+}
+
+// Formats lists every registered format name, in registration order.
+func (r *FormatRegistry) Formats() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]string, len(r.names))
+	copy(out, r.names)
+	return out
+}
+
+// Open sniffs fname against every registered format and opens it with the
+// first one whose probe matches.
+func (r *FormatRegistry) Open(fname string) (VectorBuffer, error) {
+	f, err := os.Open(fname)
+	if err != nil { return nil, err }
+	head := make([]byte, formatSniffBytes)
+	n, err := f.ReadAt(head, 0)
+	f.Close()
+	if err != nil && n == 0 { return nil, err }
+	head = head[:n]
+
+	r.mu.Lock()
+	probes := make([]FormatProbe, len(r.names))
+	opens := make([]FormatOpener, len(r.names))
+	for i, name := range r.names {
+		probes[i] = r.probes[name]
+		opens[i] = r.opens[name]
+	}
+	r.mu.Unlock()
+
+	for i, probe := range probes {
+		if probe(head) { return opens[i](fname) }
+	}
+	return nil, fmt.Errorf(
+		"%s does not match any registered VectorBuffer format.", fname,
+	)
+}
+
+// defaultFormats is the registry Register, Formats, and OpenVectorBuffer
+// operate on.
+var defaultFormats = NewFormatRegistry()
+
+// Register adds name to the default format registry. Backends call this
+// from an init function so that importing the package is enough to make
+// OpenVectorBuffer recognize their files.
+func Register(name string, probe FormatProbe, open FormatOpener) {
+	defaultFormats.Register(name, probe, open)
+}
+
+// Formats lists every format registered with the default registry.
+func Formats() []string { return defaultFormats.Formats() }
+
+// OpenVectorBuffer sniffs fname's format from its first bytes and opens it
+// with whichever registered backend recognizes it, so callers no longer
+// need to be told the snapshot format up front.
+func OpenVectorBuffer(fname string) (VectorBuffer, error) {
+	return defaultFormats.Open(fname)
+}
+
+func init() {
+	Register("gotetra", gotetraProbe, func(fname string) (VectorBuffer, error) {
+		return NewGotetraBuffer(fname)
+	})
+}
+
+// gotetraProbe recognizes a Gotetra sheet file: the endianness flag must be
+// 0 or -1, and the following four bytes must be either the v2+ "GTTR" magic
+// or a v1 header-size int32 matching RawGotetraHeader's actual size.
+func gotetraProbe(head []byte) bool {
+	if len(head) < 8 { return false }
+
+	flag := int32(binary.LittleEndian.Uint32(head[0:4]))
+	if flag != 0 && flag != -1 { return false }
+	order := endianness(flag)
+
+	var magic [4]byte
+	copy(magic[:], head[4:8])
+	if magic == gotetraMagic { return true }
+
+	headerSize := int32(order.Uint32(head[4:8]))
+	return headerSize == int32(unsafe.Sizeof(RawGotetraHeader{}))
+}
@@ -0,0 +1,42 @@
+package io
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenVectorBufferRoundTrip checks that the default registry lists at
+// least one format and that a Gotetra sheet file, the only backend this
+// package registers, is opened through OpenVectorBuffer's magic-byte
+// sniffing and reads back the positions it was written with. It does not
+// iterate over the names Formats returns.
+func TestOpenVectorBufferRoundTrip(t *testing.T) {
+	if formats := Formats(); len(formats) == 0 {
+		t.Fatal("no formats registered with the default registry")
+	}
+
+	hd := sampleGotetraHeader()
+	xs := samplePositions(int(hd.GridCount))
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "sheet.v2")
+	if err := WriteSheetHeaderAt(file, hd, xs, CompressionNone, 0); err != nil {
+		t.Fatalf("writing sheet file: %v", err)
+	}
+
+	vb, err := OpenVectorBuffer(file)
+	if err != nil { t.Fatalf("OpenVectorBuffer: %v", err) }
+
+	got, err := vb.Read(file)
+	if err != nil { t.Fatalf("Read: %v", err) }
+	vb.Close()
+
+	if len(got) != len(xs) {
+		t.Fatalf("got %d vectors, want %d", len(got), len(xs))
+	}
+	for i := range xs {
+		if got[i] != xs[i] {
+			t.Errorf("vector %d = %v, want %v", i, got[i], xs[i])
+		}
+	}
+}
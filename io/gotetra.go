@@ -3,9 +3,10 @@ package io
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"os"
-	
+
 	"unsafe"
 )
 
@@ -31,6 +32,7 @@ func NewGotetraBuffer(fname string) (VectorBuffer, error) {
 		out: make([][3]float32, sw * sw * sw),
 		open: false,
 		sw: int(sw), gw: int(gw),
+		hd: *hd,
 	}
 
 	return buf, nil
@@ -41,20 +43,36 @@ func (buf *GotetraBuffer) IsOpen() bool { return buf.open }
 func (buf *GotetraBuffer) Read(fname string) ([][3]float32, error) {
 	if buf.open { panic("Buffer already open.") }
 	buf.open = true
-	
-	err := readSheetPositionsAt(fname, buf.sheet)
+
+	f, err := os.Open(fname)
 	if err != nil { return nil, err }
-	
-	for z := 0; z < buf.sw; z++ {
-		for y := 0; y < buf.sw; y++ {
-			for x := 0; x < buf.sw; x++ {
-				si := x + y*buf.sw + z*buf.sw*buf.sw
-				gi := x + y*buf.gw + z*buf.gw*buf.gw
-				buf.out[si] = buf.sheet[gi]
-			}
-		}
+	defer f.Close()
+
+	h := &GotetraHeader{}
+	order, err := readSheetHeaderFrom(f, h)
+	if err != nil { return nil, err }
+
+	if h.GridCount != int64(len(buf.sheet)) {
+		return nil, fmt.Errorf("Position buffer has length %d, but file %s has " +
+		"%d vectors.", len(buf.sheet), fname, h.GridCount)
+	}
+
+	sec, err := positionsSection(f, h)
+	if err != nil { return nil, err }
+	raw, err := readSectionBytes(f, sec)
+	if err != nil { return nil, err }
+
+	vs := decodeVec3(raw, order)
+	if len(vs) != len(buf.sheet) {
+		return nil, fmt.Errorf("Position section of %s decodes to %d vectors, " +
+		"expected %d.", fname, len(vs), len(buf.sheet))
 	}
-	
+	copy(buf.sheet, vs)
+
+	buf.hd = *h
+
+	reshapeVec3ToSegment(buf.sheet, buf.out, buf.sw, buf.gw)
+
 	return buf.out, nil
 }
 
@@ -64,6 +82,46 @@ func (buf *GotetraBuffer) Close() {
 	buf.open = false
 }
 
+// gotetraMagic identifies a v2+ sheet file. It replaces the bare header-size
+// int32 used by v1 files in the second four-byte slot, so readSheetHeaderAt
+// can tell the formats apart before deciding how to parse the rest of the
+// header.
+var gotetraMagic = [4]byte{'G', 'T', 'T', 'R'}
+
+const (
+	// GotetraV1 is the original format: endianness flag, header-size int32,
+	// RawGotetraHeader, position block.
+	GotetraV1 = 1
+	// GotetraV2 replaces the header-size int32 with a magic/version/
+	// header-size triple, leaving room for future per-version field
+	// additions without breaking v1 readers.
+	GotetraV2 = 2
+	// GotetraV3 appends a table of contents after the RawGotetraHeader
+	// payload, turning the single implicit position block into any number
+	// of named, independently-sized sections. See gotetra_toc.go.
+	GotetraV3 = 3
+
+	gotetraLatestVersion = GotetraV3
+)
+
+// sheetPreambleSize returns the number of bytes preceding the
+// RawGotetraHeader payload for the given format version: the endianness
+// flag, the v1 header-size int32 or the v2/v3 magic/version/header-size
+// triple, and - for v2 only - the Compression/UncompressedSize pair that
+// lets a v2 file's single position block be stored compressed.
+func sheetPreambleSize(version int) int {
+	switch version {
+	case GotetraV1:
+		return 4 + 4
+	case GotetraV2:
+		return 4 + 4 + 4 + 4 + 4 + 8
+	case GotetraV3:
+		return 4 + 4 + 4 + 4
+	default:
+		panic(fmt.Sprintf("Unrecognized Gotetra version %d.", version))
+	}
+}
+
 /*
 The binary format used for phase sheets is as follows:
     |-- 1 --||-- 2 --||-- ... 3 ... --||-- ... 4 ... --||-- ... 5 ... --|
@@ -74,6 +132,11 @@ The binary format used for phase sheets is as follows:
     3 - (sheet.Header) Header file containing meta-information about the
         sheet fragment.
     4 - ([][3]float32) Contiguous block of x, y, z coordinates. Given in Mpc.
+
+Starting with v2, slot 2 instead holds a ("GTTR", version uint32,
+header-size uint32) triple. readSheetHeaderAt sniffs which layout is in
+use and dispatches to the matching decoder; both populate the same
+GotetraHeader, tagged with the version that produced it.
  */
 type RawGotetraHeader struct {
 	Cosmo                              CosmologyHeader
@@ -97,6 +160,23 @@ func (raw *RawGotetraHeader) Postprocess(hd *GotetraHeader) {
 type GotetraHeader struct {
 	RawGotetraHeader
 	N int64
+	// Version is the on-disk format version this header was decoded from
+	// (GotetraV1, GotetraV2, or GotetraV3).
+	Version int
+	// Sections is the v3 table of contents: one entry per data block the
+	// file carries (positions, velocities, IDs, masses, ...). It is empty
+	// for v1/v2 files, which always carry exactly one implicit position
+	// block.
+	Sections []SheetSection
+	// Compression is how the v2 position block is stored on disk. It is
+	// always CompressionNone for v1 and v3 files: v1 predates compression
+	// support, and v3 tracks compression per SheetSection instead.
+	Compression SheetCompression
+	// UncompressedSize is the v2 position block's size once inflated. It
+	// is set for every v2 file, whether or not Compression is none, so
+	// readers always know how many vectors to allocate without trusting
+	// GridCount*12 to agree.
+	UncompressedSize int64
 	guard struct{} // Prevents accidentally trying to write/read this type.
 }
 
@@ -113,31 +193,159 @@ func endianness(flag int32) binary.ByteOrder {
 	}
 }
 
+// readUint32 reads a single uint32 from r in the given byte order, panicking
+// on a read error the same way the package's readInt32 does.
+func readUint32(r io.Reader, order binary.ByteOrder) uint32 {
+	buf := [4]byte{}
+	if _, err := io.ReadFull(r, buf[:]); err != nil { panic(err.Error()) }
+	return order.Uint32(buf[:])
+}
+
+// readUint64 reads a single uint64 from r in the given byte order, panicking
+// on a read error the same way readUint32 does.
+func readUint64(r io.Reader, order binary.ByteOrder) uint64 {
+	buf := [8]byte{}
+	if _, err := io.ReadFull(r, buf[:]); err != nil { panic(err.Error()) }
+	return order.Uint64(buf[:])
+}
+
+// readSheetHeaderFrom decodes a Gotetra header from r, which must be
+// positioned at the start of the file (or section). It sniffs v1 vs. v2
+// and dispatches to the matching decoder, returning the byte order the
+// rest of the file was written in.
+func readSheetHeaderFrom(r io.Reader, hdBuf *GotetraHeader) (binary.ByteOrder, error) {
+	// order doesn't matter for this read, since flags are symmetric.
+	order := endianness(readInt32(r, binary.LittleEndian))
+
+	slot := [4]byte{}
+	if _, err := io.ReadFull(r, slot[:]); err != nil {
+		return binary.LittleEndian, err
+	}
+
+	var err error
+	if slot == gotetraMagic {
+		err = readSheetHeaderV2At(r, order, hdBuf)
+	} else {
+		headerSize := int32(order.Uint32(slot[:]))
+		err = readSheetHeaderV1At(r, order, headerSize, hdBuf)
+	}
+	if err != nil { return binary.LittleEndian, err }
+
+	hdBuf.RawGotetraHeader.Postprocess(hdBuf)
+
+	if hdBuf.Version >= GotetraV3 {
+		secs, err := readSheetTOCFrom(r, order)
+		if err != nil { return binary.LittleEndian, err }
+		hdBuf.Sections = secs
+	}
+
+	return order, nil
+}
+
 func readSheetHeaderAt(
 file string, hdBuf *GotetraHeader,
 ) (*os.File, binary.ByteOrder, error) {
 	f, err := os.OpenFile(file, os.O_RDONLY, os.ModePerm)
 	if err != nil { return nil, binary.LittleEndian, err }
 
-	// order doesn't matter for this read, since flags are symmetric.
-	order := endianness(readInt32(f, binary.LittleEndian))
+	order, err := readSheetHeaderFrom(f, hdBuf)
+	if err != nil { return nil, binary.LittleEndian, err }
 
-	headerSize := readInt32(f, order)
+	return f, order, nil
+}
+
+// readSheetHeaderV1At reads the RawGotetraHeader payload of a v1 sheet file.
+// r must be positioned just after the header-size int32, which the caller
+// has already validated into headerSize.
+func readSheetHeaderV1At(
+r io.Reader, order binary.ByteOrder, headerSize int32, hdBuf *GotetraHeader,
+) error {
 	if headerSize != int32(unsafe.Sizeof(RawGotetraHeader{})) {
-		return nil, binary.LittleEndian,
-		fmt.Errorf("Expected catalog.SheetHeader size of %d, found %d.",
+		return fmt.Errorf("Expected catalog.SheetHeader size of %d, found %d.",
 			unsafe.Sizeof(RawGotetraHeader{}), headerSize,
 		)
 	}
 
-	_, err = f.Seek(4 + 4, 0)
-	if err != nil { return nil, binary.LittleEndian, err }
+	if err := binary.Read(r, order, &hdBuf.RawGotetraHeader); err != nil {
+		return err
+	}
+	hdBuf.Version = GotetraV1
+	return nil
+}
 
-	err = binary.Read(f, order, &hdBuf.RawGotetraHeader)
-	if err != nil { return nil, binary.LittleEndian, err }
+// readSheetHeaderV2At reads the RawGotetraHeader payload of a v2 sheet file.
+// r must be positioned just after the "GTTR" magic.
+func readSheetHeaderV2At(
+r io.Reader, order binary.ByteOrder, hdBuf *GotetraHeader,
+) error {
+	version := readUint32(r, order)
+	headerSize := readUint32(r, order)
+
+	// Only v2 carries the Compression/UncompressedSize pair; v3 tracks
+	// compression per SheetSection in its table of contents instead.
+	if version == GotetraV2 {
+		hdBuf.Compression = SheetCompression(readUint32(r, order))
+		hdBuf.UncompressedSize = int64(readUint64(r, order))
+	}
 
-	hdBuf.RawGotetraHeader.Postprocess(hdBuf)
-	return f, order, nil
+	if headerSize != uint32(unsafe.Sizeof(RawGotetraHeader{})) {
+		return fmt.Errorf("Expected catalog.SheetHeader size of %d, found %d.",
+			unsafe.Sizeof(RawGotetraHeader{}), headerSize,
+		)
+	}
+
+	if err := binary.Read(r, order, &hdBuf.RawGotetraHeader); err != nil {
+		return err
+	}
+	hdBuf.Version = int(version)
+	return nil
+}
+
+// readVecAtOffset reads len(xsBuf) position vectors from r starting at off,
+// via ReadAt rather than a stateful Read/Seek, so callers can share one
+// underlying file across goroutines without racing on its cursor.
+func readVecAtOffset(
+r io.ReaderAt, off int64, order binary.ByteOrder, xsBuf [][3]float32,
+) error {
+	raw := make([]byte, len(xsBuf)*12)
+	if _, err := r.ReadAt(raw, off); err != nil { return err }
+
+	for i := range xsBuf {
+		for j := 0; j < 3; j++ {
+			bits := order.Uint32(raw[i*12+j*4 : i*12+j*4+4])
+			xsBuf[i][j] = math.Float32frombits(bits)
+		}
+	}
+	return nil
+}
+
+// readerAtFrom adapts an io.ReaderAt into a sequential io.Reader starting at
+// off, with no length cap. io.SectionReader needs a fixed length up front,
+// which readSheetHeaderFrom can't supply: a v3 table of contents isn't
+// sized until its section count has already been read.
+type readerAtFrom struct {
+	r   io.ReaderAt
+	off int64
+}
+
+func (s *readerAtFrom) Read(p []byte) (int, error) {
+	n, err := s.r.ReadAt(p, s.off)
+	s.off += int64(n)
+	return n, err
+}
+
+// ReadAtOffsets reads a sheet's header at headerOff and its position block
+// at dataOff out of f, using ReadAt exclusively. Unlike readSheetHeaderAt,
+// it never seeks, so many goroutines can call it concurrently against the
+// same *os.File (or any other io.ReaderAt) without synchronization.
+func ReadAtOffsets(f io.ReaderAt, headerOff, dataOff int64) ([][3]float32, error) {
+	h := &GotetraHeader{}
+	order, err := readSheetHeaderFrom(&readerAtFrom{r: f, off: headerOff}, h)
+	if err != nil { return nil, err }
+
+	xs := make([][3]float32, h.GridCount)
+	if err := readVecAtOffset(f, dataOff, order, xs); err != nil { return nil, err }
+	return xs, nil
 }
 
 // ReadHeaderAt reads the header in the given file into the target Header.
@@ -148,24 +356,45 @@ func ReadSheetHeaderAt(file string, hdBuf *GotetraHeader) error {
 	return nil
 }
 
-// ReadPositionsAt reads the velocities in the given file into a buffer.
-func readSheetPositionsAt(file string, xsBuf [][3]float32) error {
-	h := &GotetraHeader{}
-	f, order, err := readSheetHeaderAt(file, h)
-	if err != nil { return nil }
-
-	if h.GridCount != int64(len(xsBuf)) {
-		return fmt.Errorf("Position buffer has length %d, but file %s has %d " +
-		"vectors.", len(xsBuf), file, h.GridCount)
-	}
+// WriteSheetHeaderAt writes a v2 sheet file: the little-endian preamble,
+// hd's RawGotetraHeader payload, and the position block in xs, optionally
+// compressed under comp with the given level (a zlib.BestSpeed..
+// zlib.BestCompression-style knob, or <= 0 for "use the scheme's default";
+// ignored for CompressionNone). hd.Compression and hd.UncompressedSize are
+// overwritten to match what was actually written, so callers don't need to
+// populate them first. Writers that want a multi-section v3 container
+// (velocities, IDs, masses, ...) should use WriteSheetContainerAt instead.
+func WriteSheetHeaderAt(
+file string, hd *GotetraHeader, xs [][3]float32, comp SheetCompression, level int,
+) error {
+	f, err := os.Create(file)
+	if err != nil { return err }
+	defer f.Close()
 
-	// Go to block 4 in the file.
-	// The file pointer should already be here, but let's just be safe, okay?
-	f.Seek(int64(4 + 4 + int(unsafe.Sizeof(RawGotetraHeader{}))), 0)
-	if err := readVecAsByte(f, order, xsBuf); err != nil { return err }
+	order := binary.LittleEndian
 
-	if err := f.Close(); err != nil { return err }
-	return nil
+	raw := make([]byte, len(xs)*12)
+	for i, x := range xs {
+		for j := 0; j < 3; j++ {
+			order.PutUint32(raw[i*12+j*4:i*12+j*4+4], math.Float32bits(x[j]))
+		}
+	}
+	enc, err := compressBytes(raw, comp, level)
+	if err != nil { return err }
+	hd.Compression = comp
+	hd.UncompressedSize = int64(len(raw))
+
+	if err := binary.Write(f, order, int32(0)); err != nil { return err }
+	if _, err := f.Write(gotetraMagic[:]); err != nil { return err }
+	if err := binary.Write(f, order, uint32(GotetraV2)); err != nil { return err }
+	headerSize := uint32(unsafe.Sizeof(RawGotetraHeader{}))
+	if err := binary.Write(f, order, headerSize); err != nil { return err }
+	if err := binary.Write(f, order, uint32(comp)); err != nil { return err }
+	if err := binary.Write(f, order, uint64(len(raw))); err != nil { return err }
+	if err := binary.Write(f, order, hd.RawGotetraHeader); err != nil { return err }
+
+	_, err = f.Write(enc)
+	return err
 }
 
 type CellBounds struct {
@@ -0,0 +1,142 @@
+package io
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// SheetCompression names how a SheetSection's bytes are stored on disk.
+// It mirrors how debug/elf marks SHF_COMPRESSED sections: the compression
+// scheme lives entirely in the section metadata, so a reader that doesn't
+// care can still find and size the block without decoding it.
+type SheetCompression uint32
+
+const (
+	CompressionNone SheetCompression = iota
+	CompressionZlib
+	CompressionZstd
+)
+
+// compressBytes encodes data under the given scheme. level is a
+// zlib.BestSpeed..zlib.BestCompression knob for CompressionZlib, or the
+// nearest zstd.EncoderLevel for CompressionZstd; either way, level <= 0
+// means "use the scheme's default" rather than its strongest or weakest
+// setting. It is ignored for CompressionNone.
+func compressBytes(data []byte, comp SheetCompression, level int) ([]byte, error) {
+	switch comp {
+	case CompressionNone:
+		return data, nil
+	case CompressionZlib:
+		buf := &bytes.Buffer{}
+		if level <= 0 { level = zlib.DefaultCompression }
+		w, err := zlib.NewWriterLevel(buf, level)
+		if err != nil { return nil, err }
+		if _, err := w.Write(data); err != nil { return nil, err }
+		if err := w.Close(); err != nil { return nil, err }
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		buf := &bytes.Buffer{}
+		w, err := zstd.NewWriter(buf, zstd.WithEncoderLevel(zstdLevel(level)))
+		if err != nil { return nil, err }
+		if _, err := w.Write(data); err != nil { return nil, err }
+		if err := w.Close(); err != nil { return nil, err }
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("Unrecognized sheet compression %d.", comp)
+	}
+}
+
+// decompressBytes is compressBytes's inverse: given raw on-disk bytes and
+// the scheme/uncompressed size recorded in a SheetSection, it returns the
+// original block.
+func decompressBytes(raw []byte, comp SheetCompression, uncompressedSize int64) ([]byte, error) {
+	switch comp {
+	case CompressionNone:
+		return raw, nil
+	case CompressionZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil { return nil, err }
+		defer zr.Close()
+		out := make([]byte, uncompressedSize)
+		if _, err := io.ReadFull(zr, out); err != nil { return nil, err }
+		return out, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(raw))
+		if err != nil { return nil, err }
+		defer zr.Close()
+		out := make([]byte, uncompressedSize)
+		if _, err := io.ReadFull(zr, out); err != nil { return nil, err }
+		return out, nil
+	default:
+		return nil, fmt.Errorf("Unrecognized sheet compression %d.", comp)
+	}
+}
+
+// zstdLevel maps a zlib-style 1..9 (or <=0 for "default") level knob onto
+// the nearest zstd.EncoderLevel, so callers can use one Level field on
+// SheetBlock regardless of which scheme they pick.
+func zstdLevel(level int) zstd.EncoderLevel {
+	switch {
+	case level <= 0:
+		return zstd.SpeedDefault
+	case level <= 2:
+		return zstd.SpeedFastest
+	case level <= 5:
+		return zstd.SpeedDefault
+	case level <= 8:
+		return zstd.SpeedBetterCompression
+	default:
+		return zstd.SpeedBestCompression
+	}
+}
+
+// readSectionBytes reads sec's stored bytes out of r, verifying them against
+// sec.Checksum if one was recorded, and inflates them if sec.Compression is
+// set, returning the section's original, uncompressed contents.
+func readSectionBytes(r io.ReaderAt, sec SheetSection) ([]byte, error) {
+	raw := make([]byte, sec.Size)
+	if _, err := r.ReadAt(raw, sec.Offset); err != nil { return nil, err }
+
+	if sec.Checksum != 0 {
+		if sum := crc32.ChecksumIEEE(raw); sum != sec.Checksum {
+			return nil, fmt.Errorf(
+				"Section %q has checksum %#08x, expected %#08x.",
+				sec.Name, sum, sec.Checksum,
+			)
+		}
+	}
+
+	return decompressBytes(raw, sec.Compression, sec.UncompressedSize)
+}
+
+func decodeVec3(raw []byte, order binary.ByteOrder) [][3]float32 {
+	out := make([][3]float32, len(raw)/12)
+	for i := range out {
+		for j := 0; j < 3; j++ {
+			bits := order.Uint32(raw[i*12+j*4 : i*12+j*4+4])
+			out[i][j] = math.Float32frombits(bits)
+		}
+	}
+	return out
+}
+
+func decodeU64(raw []byte, order binary.ByteOrder) []uint64 {
+	out := make([]uint64, len(raw)/8)
+	for i := range out { out[i] = order.Uint64(raw[i*8 : i*8+8]) }
+	return out
+}
+
+func decodeF32(raw []byte, order binary.ByteOrder) []float32 {
+	out := make([]float32, len(raw)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(order.Uint32(raw[i*4 : i*4+4]))
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package io
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+// positionOffsetForTest computes the position block's starting offset
+// independently of positionsSection, so this test doesn't just check that
+// ReadAtOffsets agrees with the very code it's meant to be an alternative to.
+func positionOffsetForTest(t *testing.T, file string, hd *GotetraHeader) int64 {
+	if hd.Version >= GotetraV3 {
+		sec, ok := findSection(hd, SheetPositions)
+		if !ok { t.Fatalf("%s: no position section in table of contents", file) }
+		return sec.Offset
+	}
+	return int64(sheetPreambleSize(hd.Version)) + int64(unsafe.Sizeof(RawGotetraHeader{}))
+}
+
+// TestReadAtOffsets checks that ReadAtOffsets, the package's lock-free,
+// ReadAt-only entry point for concurrent sheet ingestion, reads the same
+// positions GotetraBuffer.Read would for v1, v2, and v3 files.
+func TestReadAtOffsets(t *testing.T) {
+	hd := sampleGotetraHeader()
+	xs := samplePositions(int(hd.GridCount))
+	dir := t.TempDir()
+
+	v1File := filepath.Join(dir, "sheet.v1")
+	if err := writeV1SheetAt(v1File, hd, xs); err != nil {
+		t.Fatalf("writing v1 file: %v", err)
+	}
+
+	v2File := filepath.Join(dir, "sheet.v2")
+	if err := WriteSheetHeaderAt(v2File, hd, xs, CompressionNone, 0); err != nil {
+		t.Fatalf("writing v2 file: %v", err)
+	}
+
+	v3File := filepath.Join(dir, "sheet.v3")
+	blocks := []SheetBlock{
+		{Kind: SheetPositions, Name: "positions", Data: encodeVec3(xs, binary.LittleEndian)},
+	}
+	if err := WriteSheetContainerAt(v3File, hd, blocks); err != nil {
+		t.Fatalf("writing v3 file: %v", err)
+	}
+
+	for _, file := range []string{v1File, v2File, v3File} {
+		hdOut := &GotetraHeader{}
+		if err := ReadSheetHeaderAt(file, hdOut); err != nil {
+			t.Fatalf("%s: ReadSheetHeaderAt: %v", file, err)
+		}
+		dataOff := positionOffsetForTest(t, file, hdOut)
+
+		f, err := os.Open(file)
+		if err != nil { t.Fatalf("%s: opening: %v", file, err) }
+		got, err := ReadAtOffsets(f, 0, dataOff)
+		f.Close()
+		if err != nil { t.Fatalf("%s: ReadAtOffsets: %v", file, err) }
+
+		if len(got) != len(xs) {
+			t.Fatalf("%s: got %d vectors, want %d", file, len(got), len(xs))
+		}
+		for i := range xs {
+			if got[i] != xs[i] {
+				t.Errorf("%s: vector %d = %v, want %v", file, i, got[i], xs[i])
+			}
+		}
+	}
+}
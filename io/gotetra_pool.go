@@ -0,0 +1,83 @@
+package io
+
+import (
+	"sync"
+)
+
+// GotetraPool fans Read calls for many sheet files out across a bounded
+// pool of workers, buffers, and open files. It exists because GotetraBuffer
+// on its own is single-use-at-a-time (Read panics if called while already
+// open), which forces serial I/O across the thousands of sheet fragments a
+// halo finding run touches. A GotetraPool instead hands each worker its own
+// buffer and file handle, so halo finding becomes I/O-bound on disk
+// bandwidth rather than on one goroutine's syscalls.
+type GotetraPool struct {
+	bufs    chan *GotetraBuffer
+	fileSem chan struct{}
+	workSem chan struct{}
+}
+
+// NewGotetraPool creates a pool with nBufs reusable GotetraBuffers (sized
+// from sampleFile's header), at most nFiles files open at once, and at most
+// nWorkers Read calls in flight at once. sampleFile must have the same
+// sheet dimensions (SegmentWidth, GridWidth) as every file the pool will
+// later read.
+func NewGotetraPool(sampleFile string, nBufs, nFiles, nWorkers int) (*GotetraPool, error) {
+	p := &GotetraPool{
+		bufs:    make(chan *GotetraBuffer, nBufs),
+		fileSem: make(chan struct{}, nFiles),
+		workSem: make(chan struct{}, nWorkers),
+	}
+
+	for i := 0; i < nBufs; i++ {
+		vb, err := NewGotetraBuffer(sampleFile)
+		if err != nil { return nil, err }
+		p.bufs <- vb.(*GotetraBuffer)
+	}
+	for i := 0; i < nFiles; i++ { p.fileSem <- struct{}{} }
+
+	return p, nil
+}
+
+// ReadAll reads every file in fnames, running up to nWorkers reads
+// concurrently, and returns their position vectors in the same order as
+// fnames regardless of which file finishes first.
+func (p *GotetraPool) ReadAll(fnames []string) ([][][3]float32, error) {
+	out := make([][][3]float32, len(fnames))
+	errs := make([]error, len(fnames))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(fnames))
+	for i := range fnames {
+		i := i
+		p.workSem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-p.workSem }()
+
+			<-p.fileSem
+			defer func() { p.fileSem <- struct{}{} }()
+
+			buf := <-p.bufs
+			defer func() { p.bufs <- buf }()
+
+			xs, err := buf.Read(fnames[i])
+			if err != nil {
+				errs[i] = err
+				buf.Close()
+				return
+			}
+
+			cp := make([][3]float32, len(xs))
+			copy(cp, xs)
+			out[i] = cp
+			buf.Close()
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil { return nil, err }
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package io
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// benchmarkSheetFiles writes n identical v2 sheet files to a fresh temp
+// directory, returning their paths.
+func benchmarkSheetFiles(b *testing.B, n int) []string {
+	dir := b.TempDir()
+	hd := sampleGotetraHeader()
+	xs := samplePositions(int(hd.GridCount))
+
+	files := make([]string, n)
+	for i := 0; i < n; i++ {
+		files[i] = filepath.Join(dir, fmt.Sprintf("sheet%d.v2", i))
+		if err := WriteSheetHeaderAt(files[i], hd, xs, CompressionNone, 0); err != nil {
+			b.Fatalf("writing sheet %d: %v", i, err)
+		}
+	}
+	return files
+}
+
+// BenchmarkSerialRead reads every file in turn through a single
+// GotetraBuffer, the way callers had to before GotetraPool existed.
+func BenchmarkSerialRead(b *testing.B) {
+	files := benchmarkSheetFiles(b, 64)
+	buf, err := NewGotetraBuffer(files[0])
+	if err != nil { b.Fatalf("NewGotetraBuffer: %v", err) }
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, file := range files {
+			if _, err := buf.Read(file); err != nil { b.Fatalf("Read: %v", err) }
+			buf.Close()
+		}
+	}
+}
+
+// BenchmarkPoolRead reads the same files concurrently through a
+// GotetraPool with 8 buffers, 8 open files, and 8 workers.
+func BenchmarkPoolRead(b *testing.B) {
+	files := benchmarkSheetFiles(b, 64)
+	pool, err := NewGotetraPool(files[0], 8, 8, 8)
+	if err != nil { b.Fatalf("NewGotetraPool: %v", err) }
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pool.ReadAll(files); err != nil { b.Fatalf("ReadAll: %v", err) }
+	}
+}
@@ -0,0 +1,109 @@
+package io
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+// sampleGotetraHeader returns a small, self-consistent header shared by the
+// package's tests: a single 2x2x2 segment covering an equally-sized grid,
+// so Read's crop from grid to segment is a no-op and positions round-trip
+// unchanged.
+func sampleGotetraHeader() *GotetraHeader {
+	hd := &GotetraHeader{}
+	hd.SegmentWidth, hd.GridWidth = 2, 2
+	hd.GridCount = hd.GridWidth * hd.GridWidth * hd.GridWidth
+	hd.CountWidth = 2
+	hd.Cells = 1
+	hd.Mass = 1
+	hd.TotalWidth = 1
+	return hd
+}
+
+func samplePositions(n int) [][3]float32 {
+	r := rand.New(rand.NewSource(1))
+	xs := make([][3]float32, n)
+	for i := range xs {
+		xs[i] = [3]float32{r.Float32(), r.Float32(), r.Float32()}
+	}
+	return xs
+}
+
+// writeV1SheetAt writes a v1 sheet file by hand: the package's writers only
+// produce v2 (WriteSheetHeaderAt) and v3 (WriteSheetContainerAt) files,
+// since new files have no reason to be written in the legacy format.
+func writeV1SheetAt(file string, hd *GotetraHeader, xs [][3]float32) error {
+	f, err := os.Create(file)
+	if err != nil { return err }
+	defer f.Close()
+
+	order := binary.LittleEndian
+	if err := binary.Write(f, order, int32(0)); err != nil { return err }
+	headerSize := int32(unsafe.Sizeof(RawGotetraHeader{}))
+	if err := binary.Write(f, order, headerSize); err != nil { return err }
+	if err := binary.Write(f, order, hd.RawGotetraHeader); err != nil { return err }
+
+	raw := make([]byte, len(xs)*12)
+	for i, x := range xs {
+		for j := 0; j < 3; j++ {
+			order.PutUint32(raw[i*12+j*4:i*12+j*4+4], math.Float32bits(x[j]))
+		}
+	}
+	_, err = f.Write(raw)
+	return err
+}
+
+// TestReadCompatibilityMatrix checks that v1 and v2 sheet files carrying the
+// same logical data both report the version they were actually written as
+// and round-trip to the same positions through GotetraBuffer.
+func TestReadCompatibilityMatrix(t *testing.T) {
+	hd := sampleGotetraHeader()
+	xs := samplePositions(int(hd.GridCount))
+
+	dir := t.TempDir()
+	v1File := filepath.Join(dir, "sheet.v1")
+	v2File := filepath.Join(dir, "sheet.v2")
+
+	if err := writeV1SheetAt(v1File, hd, xs); err != nil {
+		t.Fatalf("writing v1 file: %v", err)
+	}
+	if err := WriteSheetHeaderAt(v2File, hd, xs, CompressionNone, 0); err != nil {
+		t.Fatalf("writing v2 file: %v", err)
+	}
+
+	for _, tt := range []struct {
+		file    string
+		version int
+	}{
+		{v1File, GotetraV1},
+		{v2File, GotetraV2},
+	} {
+		hdOut := &GotetraHeader{}
+		if err := ReadSheetHeaderAt(tt.file, hdOut); err != nil {
+			t.Fatalf("%s: reading header: %v", tt.file, err)
+		}
+		if hdOut.Version != tt.version {
+			t.Errorf("%s: got version %d, want %d", tt.file, hdOut.Version, tt.version)
+		}
+
+		buf, err := NewGotetraBuffer(tt.file)
+		if err != nil { t.Fatalf("%s: NewGotetraBuffer: %v", tt.file, err) }
+		got, err := buf.Read(tt.file)
+		if err != nil { t.Fatalf("%s: Read: %v", tt.file, err) }
+		buf.Close()
+
+		if len(got) != len(xs) {
+			t.Fatalf("%s: got %d vectors, want %d", tt.file, len(got), len(xs))
+		}
+		for i := range xs {
+			if got[i] != xs[i] {
+				t.Errorf("%s: vector %d = %v, want %v", tt.file, i, got[i], xs[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package io
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"unsafe"
+)
+
+// SheetKind identifies the kind of data a SheetSection holds.
+type SheetKind uint32
+
+const (
+	SheetPositions SheetKind = iota
+	SheetVelocities
+	SheetIDs
+	SheetMasses
+)
+
+func (k SheetKind) String() string {
+	switch k {
+	case SheetPositions:
+		return "positions"
+	case SheetVelocities:
+		return "velocities"
+	case SheetIDs:
+		return "ids"
+	case SheetMasses:
+		return "masses"
+	default:
+		return fmt.Sprintf("SheetKind(%d)", uint32(k))
+	}
+}
+
+// SheetSection describes one block in a v3 sheet container: what it holds,
+// where it lives in the file, how (if at all) it's compressed, and a
+// checksum writers must fill in so readers can detect corruption without
+// decoding the block. Size is the number of bytes stored on disk; when
+// Compression is not CompressionNone that is the compressed size, and
+// UncompressedSize is the size of the block once inflated.
+type SheetSection struct {
+	Kind             SheetKind
+	Name             string
+	Offset           int64
+	Size             int64
+	Compression      SheetCompression
+	UncompressedSize int64
+	Checksum         uint32
+}
+
+// rawSheetSection is SheetSection's fixed-width on-disk encoding. Name is
+// padded with NUL bytes; readSheetTOCFrom trims them back off.
+type rawSheetSection struct {
+	Kind             uint32
+	Name             [16]byte
+	Offset           int64
+	Size             int64
+	Compression      uint32
+	UncompressedSize int64
+	Checksum         uint32
+}
+
+// readSheetTOCFrom reads a v3 table of contents: a section count followed by
+// that many rawSheetSection entries. r must be positioned immediately after
+// the RawGotetraHeader payload.
+func readSheetTOCFrom(r io.Reader, order binary.ByteOrder) ([]SheetSection, error) {
+	count := readUint32(r, order)
+	secs := make([]SheetSection, count)
+	for i := range secs {
+		raw := rawSheetSection{}
+		if err := binary.Read(r, order, &raw); err != nil { return nil, err }
+		secs[i] = SheetSection{
+			Kind:             SheetKind(raw.Kind),
+			Name:             strings.TrimRight(string(raw.Name[:]), "\x00"),
+			Offset:           raw.Offset,
+			Size:             raw.Size,
+			Compression:      SheetCompression(raw.Compression),
+			UncompressedSize: raw.UncompressedSize,
+			Checksum:         raw.Checksum,
+		}
+	}
+	return secs, nil
+}
+
+// writeSheetTOCAt writes the table of contents format readSheetTOCFrom
+// expects. Names longer than 16 bytes are truncated to 16.
+func writeSheetTOCAt(w io.Writer, order binary.ByteOrder, secs []SheetSection) error {
+	if err := binary.Write(w, order, uint32(len(secs))); err != nil { return err }
+	for _, s := range secs {
+		raw := rawSheetSection{
+			Kind: uint32(s.Kind), Offset: s.Offset, Size: s.Size,
+			Compression: uint32(s.Compression), UncompressedSize: s.UncompressedSize,
+			Checksum: s.Checksum,
+		}
+		copy(raw.Name[:], s.Name)
+		if err := binary.Write(w, order, raw); err != nil { return err }
+	}
+	return nil
+}
+
+// findSection looks up kind in h's table of contents.
+func findSection(h *GotetraHeader, kind SheetKind) (SheetSection, bool) {
+	for _, s := range h.Sections {
+		if s.Kind == kind { return s, true }
+	}
+	return SheetSection{}, false
+}
+
+// positionsSection returns the position block's section, synthesizing one
+// from the implicit v1/v2 layout when the file predates the v3 table of
+// contents. v1 files are always uncompressed; v2 files carry Compression
+// and UncompressedSize in their header, but not the on-disk (compressed)
+// size, so f is stat'd to recover it from the file's length - the position
+// block is always the last thing in a v1/v2 file.
+func positionsSection(f *os.File, h *GotetraHeader) (SheetSection, error) {
+	if h.Version >= GotetraV3 {
+		if sec, ok := findSection(h, SheetPositions); ok { return sec, nil }
+		return SheetSection{}, fmt.Errorf("Sheet file has no position section.")
+	}
+
+	uncompressedSize := h.GridCount * 12
+	comp := CompressionNone
+	if h.Version == GotetraV2 {
+		comp = h.Compression
+		if h.UncompressedSize != 0 { uncompressedSize = h.UncompressedSize }
+	}
+
+	off := int64(sheetPreambleSize(h.Version)) +
+		int64(unsafe.Sizeof(RawGotetraHeader{}))
+
+	size := uncompressedSize
+	if comp != CompressionNone {
+		info, err := f.Stat()
+		if err != nil { return SheetSection{}, err }
+		size = info.Size() - off
+	}
+
+	return SheetSection{
+		Kind: SheetPositions, Offset: off, Size: size,
+		Compression: comp, UncompressedSize: uncompressedSize,
+	}, nil
+}
+
+// reshapeVec3ToSegment copies the sw x sw x sw corner of the gw x gw x gw
+// grid src into dst, the same cropping Read applies to positions.
+func reshapeVec3ToSegment(src, dst [][3]float32, sw, gw int) {
+	for z := 0; z < sw; z++ {
+		for y := 0; y < sw; y++ {
+			for x := 0; x < sw; x++ {
+				si := x + y*sw + z*sw*sw
+				gi := x + y*gw + z*gw*gw
+				dst[si] = src[gi]
+			}
+		}
+	}
+}
+
+func reshapeU64ToSegment(src, dst []uint64, sw, gw int) {
+	for z := 0; z < sw; z++ {
+		for y := 0; y < sw; y++ {
+			for x := 0; x < sw; x++ {
+				si := x + y*sw + z*sw*sw
+				gi := x + y*gw + z*gw*gw
+				dst[si] = src[gi]
+			}
+		}
+	}
+}
+
+func reshapeF32ToSegment(src, dst []float32, sw, gw int) {
+	for z := 0; z < sw; z++ {
+		for y := 0; y < sw; y++ {
+			for x := 0; x < sw; x++ {
+				si := x + y*sw + z*sw*sw
+				gi := x + y*gw + z*gw*gw
+				dst[si] = src[gi]
+			}
+		}
+	}
+}
+
+// Has reports whether the last file this buffer read carries a section of
+// the given kind. Positions are always available, even for pre-v3 files,
+// since the format has always carried exactly one implicit position block.
+//
+// Has, ReadVelocities, ReadIDs, and ReadMasses are declared on
+// *GotetraBuffer rather than on VectorBuffer: callers that only hold a
+// VectorBuffer (e.g. the value OpenVectorBuffer returns) need a type
+// assertion back to *GotetraBuffer to reach them. Promoting them onto
+// VectorBuffer would force every other backend to implement all four
+// methods, including ones with no velocity/ID/mass data of their own.
+func (buf *GotetraBuffer) Has(kind SheetKind) bool {
+	if kind == SheetPositions { return true }
+	_, ok := findSection(&buf.hd, kind)
+	return ok
+}
+
+// ReadVelocities reads the velocity section of fname, cropped to this
+// buffer's segment the same way Read crops positions, transparently
+// inflating it first if it was written compressed. It returns an error
+// for v1/v2 files and v3 files without a velocity section; check Has first
+// to fall back gracefully.
+func (buf *GotetraBuffer) ReadVelocities(fname string) ([][3]float32, error) {
+	f, err := os.Open(fname)
+	if err != nil { return nil, err }
+	defer f.Close()
+
+	h := &GotetraHeader{}
+	order, err := readSheetHeaderFrom(f, h)
+	if err != nil { return nil, err }
+	buf.hd = *h
+
+	sec, ok := findSection(h, SheetVelocities)
+	if !ok {
+		return nil, fmt.Errorf("File %s has no velocity section.", fname)
+	}
+	if sec.UncompressedSize != int64(len(buf.sheet))*12 {
+		return nil, fmt.Errorf("File %s has a %d byte velocity section, " +
+		"expected %d.", fname, sec.UncompressedSize, int64(len(buf.sheet))*12)
+	}
+
+	raw, err := readSectionBytes(f, sec)
+	if err != nil { return nil, err }
+	vs := decodeVec3(raw, order)
+
+	out := make([][3]float32, len(buf.out))
+	reshapeVec3ToSegment(vs, out, buf.sw, buf.gw)
+	return out, nil
+}
+
+// ReadIDs reads the particle ID section of fname, cropped to this buffer's
+// segment the same way Read crops positions, transparently inflating it
+// first if it was written compressed.
+func (buf *GotetraBuffer) ReadIDs(fname string) ([]uint64, error) {
+	f, err := os.Open(fname)
+	if err != nil { return nil, err }
+	defer f.Close()
+
+	h := &GotetraHeader{}
+	order, err := readSheetHeaderFrom(f, h)
+	if err != nil { return nil, err }
+	buf.hd = *h
+
+	sec, ok := findSection(h, SheetIDs)
+	if !ok { return nil, fmt.Errorf("File %s has no ID section.", fname) }
+	n := int64(len(buf.sheet))
+	if sec.UncompressedSize != n*8 {
+		return nil, fmt.Errorf("File %s has a %d byte ID section, " +
+		"expected %d.", fname, sec.UncompressedSize, n*8)
+	}
+
+	raw, err := readSectionBytes(f, sec)
+	if err != nil { return nil, err }
+	ids := decodeU64(raw, order)
+
+	out := make([]uint64, len(buf.out))
+	reshapeU64ToSegment(ids, out, buf.sw, buf.gw)
+	return out, nil
+}
+
+// ReadMasses reads the particle mass section of fname, cropped to this
+// buffer's segment the same way Read crops positions, transparently
+// inflating it first if it was written compressed.
+func (buf *GotetraBuffer) ReadMasses(fname string) ([]float32, error) {
+	f, err := os.Open(fname)
+	if err != nil { return nil, err }
+	defer f.Close()
+
+	h := &GotetraHeader{}
+	order, err := readSheetHeaderFrom(f, h)
+	if err != nil { return nil, err }
+	buf.hd = *h
+
+	sec, ok := findSection(h, SheetMasses)
+	if !ok { return nil, fmt.Errorf("File %s has no mass section.", fname) }
+	n := int64(len(buf.sheet))
+	if sec.UncompressedSize != n*4 {
+		return nil, fmt.Errorf("File %s has a %d byte mass section, " +
+		"expected %d.", fname, sec.UncompressedSize, n*4)
+	}
+
+	raw, err := readSectionBytes(f, sec)
+	if err != nil { return nil, err }
+	masses := decodeF32(raw, order)
+
+	out := make([]float32, len(buf.out))
+	reshapeF32ToSegment(masses, out, buf.sw, buf.gw)
+	return out, nil
+}
+
+// SheetBlock is one section's worth of already-encoded little-endian bytes,
+// ready to be laid out into a v3 container by WriteSheetContainerAt. When
+// Compression is not CompressionNone, Data is compressed before it is
+// written, with Level passed through to the chosen compressor (a
+// zlib.BestSpeed..zlib.BestCompression-style knob; ignored for
+// CompressionNone).
+type SheetBlock struct {
+	Kind        SheetKind
+	Name        string
+	Data        []byte
+	Compression SheetCompression
+	Level       int
+}
+
+// WriteSheetContainerAt writes a v3 sheet file: preamble, RawGotetraHeader,
+// table of contents, then each block's bytes back to back in the order
+// given. Every section's offset, checksum, and (when requested) compressed
+// size are computed here, so callers only need to supply raw block data.
+func WriteSheetContainerAt(file string, hd *GotetraHeader, blocks []SheetBlock) error {
+	f, err := os.Create(file)
+	if err != nil { return err }
+	defer f.Close()
+
+	order := binary.LittleEndian
+	if err := binary.Write(f, order, int32(0)); err != nil { return err }
+	if _, err := f.Write(gotetraMagic[:]); err != nil { return err }
+	if err := binary.Write(f, order, uint32(GotetraV3)); err != nil { return err }
+	headerSize := uint32(unsafe.Sizeof(RawGotetraHeader{}))
+	if err := binary.Write(f, order, headerSize); err != nil { return err }
+	if err := binary.Write(f, order, hd.RawGotetraHeader); err != nil { return err }
+
+	// binary.Size, not unsafe.Sizeof: writeSheetTOCAt serializes each
+	// section field-by-field via binary.Write, which packs with none of
+	// the alignment padding Go's in-memory struct layout would add.
+	tocSize := int64(4 + len(blocks)*binary.Size(rawSheetSection{}))
+	off := int64(sheetPreambleSize(GotetraV3)) + int64(headerSize) + tocSize
+
+	stored := make([][]byte, len(blocks))
+	secs := make([]SheetSection, len(blocks))
+	for i, b := range blocks {
+		enc, err := compressBytes(b.Data, b.Compression, b.Level)
+		if err != nil { return err }
+		stored[i] = enc
+
+		secs[i] = SheetSection{
+			Kind: b.Kind, Name: b.Name, Offset: off,
+			Size: int64(len(enc)), Compression: b.Compression,
+			UncompressedSize: int64(len(b.Data)),
+			Checksum:         crc32.ChecksumIEEE(enc),
+		}
+		off += int64(len(enc))
+	}
+
+	if err := writeSheetTOCAt(f, order, secs); err != nil { return err }
+	for _, enc := range stored {
+		if _, err := f.Write(enc); err != nil { return err }
+	}
+	return nil
+}